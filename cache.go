@@ -0,0 +1,201 @@
+package dataloader
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache is the memoization store used by a Dataloader. Implementations must be safe for
+// concurrent use by multiple goroutines.
+type Cache[K comparable, V any] interface {
+	// Get returns the cached value for key, and whether it was present.
+	Get(key K) (V, bool)
+	// Set stores value for key.
+	Set(key K, value V)
+	// Delete removes any cached value for key.
+	Delete(key K)
+	// Clear removes all cached values.
+	Clear()
+}
+
+// NewMapCache returns the unbounded, in-memory Cache used by default when Config.Cache is unset.
+func NewMapCache[K comparable, V any]() Cache[K, V] {
+	return &mapCache[K, V]{data: make(map[K]V)}
+}
+
+type mapCache[K comparable, V any] struct {
+	mu   sync.Mutex
+	data map[K]V
+}
+
+func (c *mapCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.data[key]
+	return v, ok
+}
+
+func (c *mapCache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = value
+}
+
+func (c *mapCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+}
+
+func (c *mapCache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data = make(map[K]V)
+}
+
+// NoCache is a Cache that never stores anything, for loaders that only want request batching
+// without memoization.
+type NoCache[K comparable, V any] struct{}
+
+// Get always reports a miss.
+func (NoCache[K, V]) Get(_ K) (V, bool) {
+	var zero V
+	return zero, false
+}
+
+// Set is a no-op.
+func (NoCache[K, V]) Set(_ K, _ V) {}
+
+// Delete is a no-op.
+func (NoCache[K, V]) Delete(_ K) {}
+
+// Clear is a no-op.
+func (NoCache[K, V]) Clear() {}
+
+// NewLRUCache returns a Cache bounded to at most size entries, evicting the least recently used
+// entry once a new key would exceed size.
+func NewLRUCache[K comparable, V any](size int) Cache[K, V] {
+	if size <= 0 {
+		panic("dataloader: NewLRUCache size must be positive")
+	}
+	return &lruCache[K, V]{
+		size:    size,
+		entries: make(map[K]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+type lruCache[K comparable, V any] struct {
+	mu      sync.Mutex
+	size    int
+	entries map[K]*list.Element
+	order   *list.List // front is most recently used, back is least recently used
+}
+
+func (c *lruCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*lruEntry[K, V]).value, true
+}
+
+func (c *lruCache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*lruEntry[K, V]).value = value
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[K, V]).key)
+	}
+}
+
+func (c *lruCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+}
+
+func (c *lruCache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[K]*list.Element, c.size)
+	c.order.Init()
+}
+
+// NewTTLCache wraps cache so that entries are treated as absent once ttl has elapsed since they
+// were last Set.
+func NewTTLCache[K comparable, V any](cache Cache[K, V], ttl time.Duration) Cache[K, V] {
+	return &ttlCache[K, V]{
+		cache: cache,
+		ttl:   ttl,
+		since: make(map[K]time.Time),
+	}
+}
+
+type ttlCache[K comparable, V any] struct {
+	mu    sync.Mutex
+	cache Cache[K, V]
+	ttl   time.Duration
+	since map[K]time.Time
+}
+
+func (c *ttlCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	setAt, ok := c.since[key]
+	expired := ok && time.Since(setAt) > c.ttl
+	if expired {
+		delete(c.since, key)
+	}
+	c.mu.Unlock()
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	if expired {
+		c.cache.Delete(key)
+		var zero V
+		return zero, false
+	}
+	return c.cache.Get(key)
+}
+
+func (c *ttlCache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	c.since[key] = time.Now()
+	c.mu.Unlock()
+	c.cache.Set(key, value)
+}
+
+func (c *ttlCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	delete(c.since, key)
+	c.mu.Unlock()
+	c.cache.Delete(key)
+}
+
+func (c *ttlCache[K, V]) Clear() {
+	c.mu.Lock()
+	c.since = make(map[K]time.Time)
+	c.mu.Unlock()
+	c.cache.Clear()
+}
@@ -0,0 +1,66 @@
+package dataloader_test
+
+import (
+	"testing"
+	"time"
+
+	"go.einride.tech/dataloader"
+)
+
+func TestLRUCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := dataloader.NewLRUCache[string, int](2)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	// a was just touched, so b is now the least recently used entry and should be evicted.
+	c.Set("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to have been evicted")
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("expected a to survive eviction, got %v, %v", v, ok)
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Errorf("expected c to be cached, got %v, %v", v, ok)
+	}
+}
+
+func TestLRUCacheDeleteAndClear(t *testing.T) {
+	c := dataloader.NewLRUCache[string, int](2)
+	c.Set("a", 1)
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected a to be deleted")
+	}
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Clear()
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected the cache to be empty after Clear")
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected the cache to be empty after Clear")
+	}
+}
+
+func TestTTLCacheExpiry(t *testing.T) {
+	c := dataloader.NewTTLCache[string, int](dataloader.NewMapCache[string, int](), 10*time.Millisecond)
+	c.Set("a", 1)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("expected a to be cached immediately, got %v, %v", v, ok)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected a to have expired")
+	}
+}
+
+func TestNoCacheNeverStores(t *testing.T) {
+	var c dataloader.NoCache[string, int]
+	c.Set("a", 1)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected NoCache to never store a value")
+	}
+}
@@ -2,170 +2,357 @@ package dataloader
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 )
 
 // Config for a generic dataloader.
-type Config[T any] struct {
-	// Fetch sets the function for fetching data.
-	Fetch func(ctx context.Context, keys []string) ([]T, error)
+type Config[K comparable, V any] struct {
+	// Fetch sets the function for fetching data. A non-nil error fails every key in the batch.
+	// Exactly one of Fetch and FetchWithErrors must be set.
+	Fetch func(ctx context.Context, keys []K) ([]V, error)
+	// FetchWithErrors sets the function for fetching data with a per-key error result, so a
+	// single missing or forbidden key does not fail the rest of the batch. Exactly one of Fetch
+	// and FetchWithErrors must be set.
+	FetchWithErrors func(ctx context.Context, keys []K) ([]V, []error)
 	// Wait sets the duration to wait before fetching data.
 	Wait time.Duration
 	// MaxBatch sets the max batch size when fetching data.
 	MaxBatch int
+	// MaxConcurrentBatches bounds how many batches may be in Fetch/FetchWithErrors at once. This
+	// matters when a single LoadAll/LoadAllThunk call spans more than MaxBatch keys: each
+	// MaxBatch-sized chunk is dispatched to Fetch/FetchWithErrors as soon as it fills, so a large
+	// LoadAll can otherwise have many batches in flight concurrently. Zero means unbounded.
+	MaxConcurrentBatches int
+	// Cache sets the Cache implementation used to memoize fetched values. Defaults to an
+	// unbounded in-memory map (see NewMapCache). Use NoCache to disable memoization entirely,
+	// or NewLRUCache/NewTTLCache to bound it.
+	Cache Cache[K, V]
 }
 
 // Dataloader is a generic dataloader.
-type Dataloader[T any] struct {
-	ctx    context.Context
-	config Config[T]
-	mu     sync.Mutex // protects mutable state below
-	cache  map[string]T
-	batch  *dataloaderBatch[T]
+type Dataloader[K comparable, V any] struct {
+	ctx         context.Context
+	config      Config[K, V]
+	mu          sync.Mutex // protects mutable state below
+	cache       Cache[K, V]
+	errCache    map[K]error
+	batch       *dataloaderBatch[K, V]
+	dispatchSem chan struct{} // bounds concurrent Fetch/FetchWithErrors calls; nil if unbounded
 }
 
 // New creates a new dataloader.
-func New[T any](
+//
+// It panics if config sets neither or both of Fetch and FetchWithErrors: exactly one of them
+// must be set.
+func New[K comparable, V any](
 	ctx context.Context,
-	config Config[T],
-) *Dataloader[T] {
-	return &Dataloader[T]{
+	config Config[K, V],
+) *Dataloader[K, V] {
+	switch {
+	case config.Fetch == nil && config.FetchWithErrors == nil:
+		panic("dataloader: Config.Fetch or Config.FetchWithErrors must be set")
+	case config.Fetch != nil && config.FetchWithErrors != nil:
+		panic("dataloader: Config.Fetch and Config.FetchWithErrors are mutually exclusive")
+	}
+	if config.Cache == nil {
+		config.Cache = NewMapCache[K, V]()
+	}
+	l := &Dataloader[K, V]{
 		ctx:    ctx,
 		config: config,
+		cache:  config.Cache,
 	}
+	if config.MaxConcurrentBatches > 0 {
+		l.dispatchSem = make(chan struct{}, config.MaxConcurrentBatches)
+	}
+	return l
 }
 
-type dataloaderBatch[T any] struct {
-	ctx     context.Context
-	keys    []string
-	data    []T
-	err     error
-	closing bool
-	done    chan struct{}
+// batchKeyIndexThreshold is the batch size at which keyIndex switches from a
+// linear scan over keys to a map-backed lookup, to avoid O(n²) behavior on large batches.
+const batchKeyIndexThreshold = 32
+
+type dataloaderBatch[K comparable, V any] struct {
+	ctx       context.Context // earliest-deadline context among contributing callers; guarded by Dataloader.mu
+	keys      []K
+	keyIndexM map[K]int
+	data      []V
+	errs      []error
+	err       error
+	closing   bool
+	done      chan struct{}
+	stops     []func() bool // deregisters the watch cancellation callbacks; guarded by Dataloader.mu
+	timer     *time.Timer   // debounce timer for Config.Wait; guarded by Dataloader.mu
 }
 
-// Load a result by key, batching and caching will be applied automatically.
-func (l *Dataloader[T]) Load(key string) (T, error) {
-	return l.LoadThunk(key)()
+// Load a result by key, batching and caching will be applied automatically. The batch's
+// effective deadline is the earliest deadline among ctx and those of any other callers
+// contributing keys to the same batch.
+func (l *Dataloader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	return l.LoadThunk(ctx, key)()
 }
 
 // LoadThunk returns a function that when called will block waiting for a result.
 // This method should be used if you want one goroutine to make requests to
 // different data loaders without blocking until the thunk is called.
-func (l *Dataloader[T]) LoadThunk(key string) func() (T, error) {
+func (l *Dataloader[K, V]) LoadThunk(ctx context.Context, key K) func() (V, error) {
 	l.mu.Lock()
-	if it, ok := l.cache[key]; ok {
+	if it, ok := l.cache.Get(key); ok {
 		l.mu.Unlock()
-		return func() (T, error) {
+		return func() (V, error) {
 			return it, nil
 		}
 	}
+	if err, ok := l.errCache[key]; ok {
+		l.mu.Unlock()
+		return func() (V, error) {
+			var zero V
+			return zero, err
+		}
+	}
 	if l.batch == nil {
-		l.batch = &dataloaderBatch[T]{ctx: l.ctx, done: make(chan struct{})}
+		l.batch = &dataloaderBatch[K, V]{ctx: l.ctx, done: make(chan struct{})}
 	}
 	batch := l.batch
-	pos := batch.keyIndex(l, key)
+	pos := batch.keyIndex(l, key, ctx)
 	l.mu.Unlock()
-	return func() (T, error) {
+	return func() (V, error) {
 		<-batch.done
-		var data T
+		var data V
 		if pos < len(batch.data) {
 			data = batch.data[pos]
 		}
-		if batch.err == nil {
-			l.mu.Lock()
+		var keyErr error
+		if pos < len(batch.errs) {
+			keyErr = batch.errs[pos]
+		} else {
+			keyErr = batch.err
+		}
+		l.mu.Lock()
+		if keyErr == nil {
 			l.unsafeSet(key, data)
-			l.mu.Unlock()
+		} else if pos < len(batch.errs) {
+			// Only cache per-key errors, not whole-batch fetch failures, so a transient
+			// failure of the whole batch doesn't poison the cache for every key in it.
+			l.unsafeSetErr(key, keyErr)
 		}
-		return data, batch.err
+		l.mu.Unlock()
+		return data, keyErr
 	}
 }
 
 // LoadAll fetches many keys at once.
 // It will be broken into appropriately sized sub-batches based on how the dataloader is configured.
-func (l *Dataloader[T]) LoadAll(keys []string) ([]T, error) {
-	results := make([]func() (T, error), len(keys))
-	for i, key := range keys {
-		results[i] = l.LoadThunk(key)
-	}
-	values := make([]T, len(keys))
-	var err error
-	for i, thunk := range results {
-		values[i], err = thunk()
-		if err != nil {
-			return nil, err
-		}
-	}
-	return values, nil
+func (l *Dataloader[K, V]) LoadAll(ctx context.Context, keys []K) ([]V, error) {
+	return l.LoadAllThunk(ctx, keys)()
 }
 
 // LoadAllThunk returns a function that when called will block waiting for results.
 // This method should be used if you want one goroutine to make requests to many
 // different data loaders without blocking until the thunk is called.
-func (l *Dataloader[T]) LoadAllThunk(keys []string) func() ([]T, error) {
-	results := make([]func() (T, error), len(keys))
+//
+// Each key is routed through LoadThunk, so it joins the same debounced batch (and the same
+// in-flight Fetch) as any other concurrent Load/LoadAll call for that key, rather than issuing
+// its own redundant fetch. Keys beyond a single batch (see Config.MaxBatch) still spill into
+// further batches dispatched as soon as they fill, bounded by Config.MaxConcurrentBatches.
+func (l *Dataloader[K, V]) LoadAllThunk(ctx context.Context, keys []K) func() ([]V, error) {
+	results := make([]func() (V, error), len(keys))
 	for i, key := range keys {
-		results[i] = l.LoadThunk(key)
+		results[i] = l.LoadThunk(ctx, key)
 	}
-	return func() ([]T, error) {
-		values := make([]T, len(keys))
-		var err error
+	return func() ([]V, error) {
+		values := make([]V, len(keys))
+		errs := make([]error, len(keys))
 		for i, thunk := range results {
-			values[i], err = thunk()
-			if err != nil {
-				return nil, err
-			}
+			values[i], errs[i] = thunk()
 		}
-		return values, nil
+		return values, errors.Join(errs...)
 	}
 }
 
-func (l *Dataloader[T]) unsafeSet(key string, value T) {
-	if l.cache == nil {
-		l.cache = map[string]T{}
+// fetch invokes the configured Fetch or FetchWithErrors function for keys, bounded by
+// Config.MaxConcurrentBatches. keyErrs is non-nil only when FetchWithErrors was used; batchErr is
+// the plain Fetch error otherwise.
+func (l *Dataloader[K, V]) fetch(ctx context.Context, keys []K) (data []V, keyErrs []error, batchErr error) {
+	if l.dispatchSem != nil {
+		l.dispatchSem <- struct{}{}
+		defer func() { <-l.dispatchSem }()
 	}
-	l.cache[key] = value
+	if l.config.FetchWithErrors != nil {
+		data, keyErrs = l.config.FetchWithErrors(ctx, keys)
+		return data, keyErrs, nil
+	}
+	data, batchErr = l.config.Fetch(ctx, keys)
+	return data, nil, batchErr
+}
+
+func (l *Dataloader[K, V]) unsafeSet(key K, value V) {
+	l.cache.Set(key, value)
+}
+
+// Prime seeds the cache with a value for key, as if it had already been fetched. It has no
+// effect if a value or an error for key is already cached.
+func (l *Dataloader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.cache.Get(key); ok {
+		return
+	}
+	if _, ok := l.errCache[key]; ok {
+		return
+	}
+	l.unsafeSet(key, value)
+}
+
+// Clear removes any cached value or error for key, forcing the next Load to fetch it again.
+func (l *Dataloader[K, V]) Clear(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cache.Delete(key)
+	delete(l.errCache, key)
+}
+
+// ClearAll removes every cached value and error, forcing subsequent Loads to fetch again.
+func (l *Dataloader[K, V]) ClearAll() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cache.Clear()
+	l.errCache = nil
+}
+
+func (l *Dataloader[K, V]) unsafeSetErr(key K, err error) {
+	if l.errCache == nil {
+		l.errCache = map[K]error{}
+	}
+	l.errCache[key] = err
 }
 
 // keyIndex will return the location of the key in the batch, if its not found
-// it will add the key to the batch.
-func (b *dataloaderBatch[T]) keyIndex(l *Dataloader[T], key string) int {
-	for i, existingKey := range b.keys {
-		if key == existingKey {
+// it will add the key to the batch. Once the batch reaches batchKeyIndexThreshold
+// keys, lookups are served from a map instead of scanning the keys slice.
+// The batch's effective context is narrowed to the earliest deadline between its current
+// context and ctx, and ctx's cancellation is watched so the batch aborts as soon as any
+// contributing caller's context is done, not just the one that started the timer.
+// Must be called with l.mu held.
+func (b *dataloaderBatch[K, V]) keyIndex(l *Dataloader[K, V], key K, ctx context.Context) int {
+	b.ctx = earliestContext(b.ctx, ctx)
+	b.watch(l, ctx)
+	if b.keyIndexM != nil {
+		if i, ok := b.keyIndexM[key]; ok {
 			return i
 		}
+	} else {
+		for i, existingKey := range b.keys {
+			if key == existingKey {
+				return i
+			}
+		}
 	}
 	pos := len(b.keys)
 	b.keys = append(b.keys, key)
+	if b.keyIndexM != nil {
+		b.keyIndexM[key] = pos
+	} else if pos+1 == batchKeyIndexThreshold {
+		b.keyIndexM = make(map[K]int, len(b.keys))
+		for i, k := range b.keys {
+			b.keyIndexM[k] = i
+		}
+	}
 	if pos == 0 {
-		go b.startTimer(l)
+		b.timer = time.NewTimer(l.config.Wait)
+		go b.waitTimer(l)
 	}
 	if l.config.MaxBatch != 0 && pos >= l.config.MaxBatch-1 {
 		if !b.closing {
 			b.closing = true
 			l.batch = nil
+			b.stopWatchersLocked()
+			b.stopTimerLocked()
 			go b.end(l)
 		}
 	}
 	return pos
 }
 
-func (b *dataloaderBatch[T]) startTimer(l *Dataloader[T]) {
-	// TODO: Respect context.
-	time.Sleep(l.config.Wait)
+// watch registers ctx's cancellation to abort the batch with ctx.Err() if the batch hasn't
+// already finished by the time ctx is done. Must be called with l.mu held.
+func (b *dataloaderBatch[K, V]) watch(l *Dataloader[K, V], ctx context.Context) {
+	if ctx.Done() == nil {
+		return
+	}
+	b.stops = append(b.stops, context.AfterFunc(ctx, func() {
+		l.mu.Lock()
+		if b.closing {
+			l.mu.Unlock()
+			return
+		}
+		b.closing = true
+		l.batch = nil
+		b.err = ctx.Err()
+		b.stopWatchersLocked()
+		b.stopTimerLocked()
+		l.mu.Unlock()
+		close(b.done)
+	}))
+}
+
+// stopWatchersLocked deregisters every cancellation watcher registered by watch, once the batch
+// has finished by some other means. Must be called with l.mu held.
+func (b *dataloaderBatch[K, V]) stopWatchersLocked() {
+	for _, stop := range b.stops {
+		stop()
+	}
+}
+
+// stopTimerLocked stops the batch's debounce timer once the batch has finished by some other
+// means; the waitTimer goroutine then returns when the batch's done channel is closed instead
+// of waiting for Config.Wait to elapse. Must be called with l.mu held.
+func (b *dataloaderBatch[K, V]) stopTimerLocked() {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+}
+
+func (b *dataloaderBatch[K, V]) waitTimer(l *Dataloader[K, V]) {
+	select {
+	case <-b.timer.C:
+	case <-b.done:
+		// the batch finished by some other means and its timer was stopped, so timer.C never fires
+		return
+	}
 	l.mu.Lock()
-	// we must have hit a batch limit and are already finalizing this batch
+	// we must have hit a batch limit, or been canceled, and are already finalizing this batch
 	if b.closing {
 		l.mu.Unlock()
 		return
 	}
+	b.closing = true
 	l.batch = nil
+	b.stopWatchersLocked()
 	l.mu.Unlock()
 	b.end(l)
 }
 
-func (b *dataloaderBatch[T]) end(l *Dataloader[T]) {
-	b.data, b.err = l.config.Fetch(b.ctx, b.keys)
+func (b *dataloaderBatch[K, V]) end(l *Dataloader[K, V]) {
+	b.data, b.errs, b.err = l.fetch(b.ctx, b.keys)
 	close(b.done)
 }
+
+// earliestContext returns whichever of a and b will be done soonest, preferring the one with a
+// deadline when only one has one.
+func earliestContext(a, b context.Context) context.Context {
+	ad, aok := a.Deadline()
+	bd, bok := b.Deadline()
+	switch {
+	case !aok:
+		return b
+	case !bok:
+		return a
+	case bd.Before(ad):
+		return b
+	default:
+		return a
+	}
+}
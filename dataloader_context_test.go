@@ -0,0 +1,65 @@
+package dataloader_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.einride.tech/dataloader"
+)
+
+func TestLoadRespectsContextCancellation(t *testing.T) {
+	var fetchCalled int32
+	loader := dataloader.New[string, string](context.Background(), dataloader.Config[string, string]{
+		Fetch: func(_ context.Context, keys []string) ([]string, error) {
+			atomic.StoreInt32(&fetchCalled, 1)
+			return make([]string, len(keys)), nil
+		},
+		Wait: time.Second, // long enough that only cancellation, not the timer, can end the batch
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := loader.Load(ctx, "a")
+		errCh <- err
+	}()
+	cancel()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got err %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Load did not return promptly after the context was canceled")
+	}
+	if atomic.LoadInt32(&fetchCalled) != 0 {
+		t.Error("Fetch was called despite the context being canceled before Wait elapsed")
+	}
+}
+
+// TestConcurrentLoadCancellationRace exercises keyIndex's earliest-deadline merge and the
+// cancellation watcher concurrently from many goroutines; run with -race to confirm the shared
+// batch context is never read or written without Dataloader.mu held.
+func TestConcurrentLoadCancellationRace(t *testing.T) {
+	loader := dataloader.New[int, int](context.Background(), dataloader.Config[int, int]{
+		Fetch: func(_ context.Context, keys []int) ([]int, error) {
+			return make([]int, len(keys)), nil
+		},
+		Wait: 5 * time.Millisecond,
+	})
+	const n = 50
+	done := make(chan struct{}, n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer func() { done <- struct{}{} }()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(i%3)*time.Millisecond)
+			defer cancel()
+			_, _ = loader.Load(ctx, i)
+		}(i)
+	}
+	for i := 0; i < n; i++ {
+		<-done
+	}
+}
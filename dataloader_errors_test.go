@@ -0,0 +1,95 @@
+package dataloader_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.einride.tech/dataloader"
+)
+
+func TestFetchWithErrorsCachesNegativeResult(t *testing.T) {
+	var calls int32
+	loader := dataloader.New[string, string](context.Background(), dataloader.Config[string, string]{
+		FetchWithErrors: func(_ context.Context, keys []string) ([]string, []error) {
+			atomic.AddInt32(&calls, 1)
+			values := make([]string, len(keys))
+			errs := make([]error, len(keys))
+			for i, key := range keys {
+				if key == "missing" {
+					errs[i] = errors.New("not found")
+					continue
+				}
+				values[i] = "ok:" + key
+			}
+			return values, errs
+		},
+		Wait: 2 * time.Millisecond,
+	})
+	ctx := context.Background()
+	if _, err := loader.Load(ctx, "missing"); err == nil {
+		t.Fatal("expected an error for the missing key")
+	}
+	if _, err := loader.Load(ctx, "missing"); err == nil {
+		t.Fatal("expected the cached error to be returned without refetching")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("FetchWithErrors called %d times, want 1 (the per-key error should be cached)", got)
+	}
+}
+
+func TestFetchWithErrorsDoesNotPoisonOtherKeys(t *testing.T) {
+	loader := dataloader.New[string, string](context.Background(), dataloader.Config[string, string]{
+		FetchWithErrors: func(_ context.Context, keys []string) ([]string, []error) {
+			values := make([]string, len(keys))
+			errs := make([]error, len(keys))
+			for i, key := range keys {
+				if key == "missing" {
+					errs[i] = errors.New("not found")
+					continue
+				}
+				values[i] = "ok:" + key
+			}
+			return values, errs
+		},
+		Wait: 2 * time.Millisecond,
+	})
+	got, err := loader.LoadAll(context.Background(), []string{"missing", "ok"})
+	if err == nil {
+		t.Fatal("expected a non-nil aggregated error")
+	}
+	if got[1] != "ok:ok" {
+		t.Errorf("got %q, want %q for the key that didn't error", got[1], "ok:ok")
+	}
+}
+
+func TestFetchErrorIsNotCached(t *testing.T) {
+	var calls int32
+	loader := dataloader.New[string, string](context.Background(), dataloader.Config[string, string]{
+		Fetch: func(_ context.Context, keys []string) ([]string, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return nil, errors.New("boom")
+			}
+			values := make([]string, len(keys))
+			copy(values, keys)
+			return values, nil
+		},
+		Wait: 2 * time.Millisecond,
+	})
+	ctx := context.Background()
+	if _, err := loader.Load(ctx, "a"); err == nil {
+		t.Fatal("expected the first fetch to fail")
+	}
+	got, err := loader.Load(ctx, "a")
+	if err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+	if got != "a" {
+		t.Errorf("got %q, want %q", got, "a")
+	}
+	if calls != 2 {
+		t.Errorf("Fetch called %d times, want 2 (a whole-batch error must not be cached)", calls)
+	}
+}
@@ -13,7 +13,7 @@ func ExampleDataloader() {
 		ID string
 	}
 	ctx := context.Background()
-	loader := dataloader.New[User](ctx, dataloader.Config[User]{
+	loader := dataloader.New[string, User](ctx, dataloader.Config[string, User]{
 		Fetch: func(ctx context.Context, keys []string) ([]User, error) {
 			users := make([]User, len(keys))
 			for i, key := range keys {
@@ -24,7 +24,7 @@ func ExampleDataloader() {
 		Wait:     2 * time.Millisecond,
 		MaxBatch: 100,
 	})
-	users, err := loader.LoadAll([]string{"foo", "bar"})
+	users, err := loader.LoadAll(ctx, []string{"foo", "bar"})
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,56 @@
+package dataloader_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.einride.tech/dataloader"
+)
+
+func TestDataloaderIntKeys(t *testing.T) {
+	loader := dataloader.New[int, string](context.Background(), dataloader.Config[int, string]{
+		Fetch: func(_ context.Context, keys []int) ([]string, error) {
+			values := make([]string, len(keys))
+			for i, key := range keys {
+				values[i] = fmt.Sprintf("user-%d", key)
+			}
+			return values, nil
+		},
+		Wait: 2 * time.Millisecond,
+	})
+	got, err := loader.LoadAll(context.Background(), []int{1, 2, 3})
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	want := []string{"user-1", "user-2", "user-3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDataloaderStructKeys(t *testing.T) {
+	type participantKey struct {
+		TenantID string
+		UserID   string
+	}
+	loader := dataloader.New[participantKey, string](context.Background(), dataloader.Config[participantKey, string]{
+		Fetch: func(_ context.Context, keys []participantKey) ([]string, error) {
+			values := make([]string, len(keys))
+			for i, key := range keys {
+				values[i] = key.TenantID + ":" + key.UserID
+			}
+			return values, nil
+		},
+		Wait: 2 * time.Millisecond,
+	})
+	got, err := loader.Load(context.Background(), participantKey{TenantID: "t1", UserID: "u1"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := "t1:u1"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+package dataloader_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.einride.tech/dataloader"
+)
+
+func TestLoadAllCoalescesWithConcurrentLoad(t *testing.T) {
+	var calls int32
+	loader := dataloader.New[string, string](context.Background(), dataloader.Config[string, string]{
+		Fetch: func(_ context.Context, keys []string) ([]string, error) {
+			atomic.AddInt32(&calls, 1)
+			values := make([]string, len(keys))
+			copy(values, keys)
+			return values, nil
+		},
+		Wait: 20 * time.Millisecond,
+	})
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	var loadErr, loadAllErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, loadErr = loader.Load(ctx, "1")
+	}()
+	go func() {
+		defer wg.Done()
+		_, loadAllErr = loader.LoadAll(ctx, []string{"1", "2", "3"})
+	}()
+	wg.Wait()
+	if loadErr != nil {
+		t.Fatalf("Load: %v", loadErr)
+	}
+	if loadAllErr != nil {
+		t.Fatalf("LoadAll: %v", loadAllErr)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("Fetch called %d times, want 1: Load and LoadAll should coalesce key %q into one batch", got, "1")
+	}
+}
+
+func TestLoadAllPreservesOrderAcrossSubBatches(t *testing.T) {
+	loader := dataloader.New[int, int](context.Background(), dataloader.Config[int, int]{
+		Fetch: func(_ context.Context, keys []int) ([]int, error) {
+			values := make([]int, len(keys))
+			for i, key := range keys {
+				values[i] = key * key
+			}
+			return values, nil
+		},
+		Wait:     5 * time.Millisecond,
+		MaxBatch: 2,
+	})
+	got, err := loader.LoadAll(context.Background(), []int{1, 2, 3, 4, 5})
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	want := []int{1, 4, 9, 16, 25}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMaxConcurrentBatchesBoundsFetchConcurrency(t *testing.T) {
+	var active, maxActive int32
+	loader := dataloader.New[int, int](context.Background(), dataloader.Config[int, int]{
+		Fetch: func(_ context.Context, keys []int) ([]int, error) {
+			n := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+			for {
+				m := atomic.LoadInt32(&maxActive)
+				if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			values := make([]int, len(keys))
+			copy(values, keys)
+			return values, nil
+		},
+		Wait:                 5 * time.Millisecond,
+		MaxBatch:             1,
+		MaxConcurrentBatches: 2,
+	})
+	keys := make([]int, 10)
+	for i := range keys {
+		keys[i] = i
+	}
+	if _, err := loader.LoadAll(context.Background(), keys); err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if got := atomic.LoadInt32(&maxActive); got > 2 {
+		t.Errorf("observed %d concurrent Fetch calls, want at most 2 (MaxConcurrentBatches)", got)
+	}
+}
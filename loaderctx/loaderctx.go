@@ -0,0 +1,33 @@
+// Package loaderctx provides helpers for carrying a Dataloader on a context.Context, for the
+// common case of a request-scoped loader installed by middleware and retrieved by resolvers
+// further down the call stack.
+package loaderctx
+
+import (
+	"context"
+	"fmt"
+
+	"go.einride.tech/dataloader"
+)
+
+// contextKey is a distinct type per (K, V) instantiation, so loaders of different key/value
+// types never collide on the same context.
+type contextKey[K comparable, V any] struct{}
+
+// NewContext returns a copy of ctx carrying loader, retrievable with FromContext using the same
+// K and V type arguments.
+func NewContext[K comparable, V any](ctx context.Context, loader *dataloader.Dataloader[K, V]) context.Context {
+	return context.WithValue(ctx, contextKey[K, V]{}, loader)
+}
+
+// FromContext returns the Dataloader[K, V] stored in ctx by NewContext.
+// It returns an error if ctx carries no such loader.
+func FromContext[K comparable, V any](ctx context.Context) (*dataloader.Dataloader[K, V], error) {
+	loader, ok := ctx.Value(contextKey[K, V]{}).(*dataloader.Dataloader[K, V])
+	if !ok {
+		var zeroK K
+		var zeroV V
+		return nil, fmt.Errorf("loaderctx: no dataloader.Dataloader[%T, %T] in context", zeroK, zeroV)
+	}
+	return loader, nil
+}
@@ -0,0 +1,48 @@
+package loaderctx_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.einride.tech/dataloader"
+	"go.einride.tech/dataloader/loaderctx"
+)
+
+func TestNewContextFromContextRoundTrip(t *testing.T) {
+	loader := dataloader.New[string, string](context.Background(), dataloader.Config[string, string]{
+		Fetch: func(_ context.Context, keys []string) ([]string, error) {
+			values := make([]string, len(keys))
+			copy(values, keys)
+			return values, nil
+		},
+		Wait: 2 * time.Millisecond,
+	})
+	ctx := loaderctx.NewContext(context.Background(), loader)
+	got, err := loaderctx.FromContext[string, string](ctx)
+	if err != nil {
+		t.Fatalf("FromContext: %v", err)
+	}
+	if got != loader {
+		t.Error("FromContext returned a different loader than was stored")
+	}
+}
+
+func TestFromContextMissingReturnsError(t *testing.T) {
+	if _, err := loaderctx.FromContext[string, string](context.Background()); err == nil {
+		t.Fatal("expected an error when no loader is present in the context")
+	}
+}
+
+func TestFromContextTypeMismatchReturnsError(t *testing.T) {
+	loader := dataloader.New[string, string](context.Background(), dataloader.Config[string, string]{
+		Fetch: func(_ context.Context, keys []string) ([]string, error) {
+			return make([]string, len(keys)), nil
+		},
+		Wait: 2 * time.Millisecond,
+	})
+	ctx := loaderctx.NewContext(context.Background(), loader)
+	if _, err := loaderctx.FromContext[int, string](ctx); err == nil {
+		t.Fatal("expected an error when the stored loader has different type parameters")
+	}
+}
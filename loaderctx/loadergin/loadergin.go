@@ -0,0 +1,24 @@
+// Package loadergin provides gin middleware that installs a request-scoped Dataloader into the
+// request context.
+package loadergin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"go.einride.tech/dataloader"
+	"go.einride.tech/dataloader/loaderctx"
+)
+
+// NewMiddleware returns gin middleware that builds a fresh Dataloader from newConfig for each
+// request, scoped to that request's context and lifetime, and installs it with
+// loaderctx.NewContext so downstream handlers can retrieve it with loaderctx.FromContext.
+func NewMiddleware[K comparable, V any](
+	newConfig func(c *gin.Context) dataloader.Config[K, V],
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		loader := dataloader.New(ctx, newConfig(c))
+		c.Request = c.Request.WithContext(loaderctx.NewContext(ctx, loader))
+		c.Next()
+	}
+}
@@ -0,0 +1,59 @@
+package loadergin_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go.einride.tech/dataloader"
+	"go.einride.tech/dataloader/loaderctx"
+	"go.einride.tech/dataloader/loaderctx/loadergin"
+)
+
+func TestNewMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newConfig := func(_ *gin.Context) dataloader.Config[string, string] {
+		return dataloader.Config[string, string]{
+			Fetch: func(_ context.Context, keys []string) ([]string, error) {
+				values := make([]string, len(keys))
+				copy(values, keys)
+				return values, nil
+			},
+			Wait: 2 * time.Millisecond,
+		}
+	}
+	middleware := loadergin.NewMiddleware(newConfig)
+	handle := func() *dataloader.Dataloader[string, string] {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		var loader *dataloader.Dataloader[string, string]
+		c.Handlers = gin.HandlersChain{
+			middleware,
+			func(c *gin.Context) {
+				var err error
+				loader, err = loaderctx.FromContext[string, string](c.Request.Context())
+				if err != nil {
+					t.Fatalf("FromContext: %v", err)
+				}
+				if _, err := loader.Load(c.Request.Context(), "a"); err != nil {
+					t.Errorf("Load: %v", err)
+				}
+			},
+		}
+		c.Next()
+		return loader
+	}
+	first := handle()
+	second := handle()
+	if first == nil || second == nil {
+		t.Fatal("expected both requests to resolve a loader from the context")
+	}
+	if first == second {
+		t.Error("expected each request to get its own Dataloader instance, got the same one twice")
+	}
+}
@@ -0,0 +1,25 @@
+// Package loaderhttp provides net/http middleware that installs a request-scoped Dataloader
+// into the request context.
+package loaderhttp
+
+import (
+	"net/http"
+
+	"go.einride.tech/dataloader"
+	"go.einride.tech/dataloader/loaderctx"
+)
+
+// NewMiddleware returns net/http middleware that builds a fresh Dataloader from newConfig for
+// each request, scoped to that request's context and lifetime, and installs it with
+// loaderctx.NewContext so downstream handlers can retrieve it with loaderctx.FromContext.
+func NewMiddleware[K comparable, V any](
+	newConfig func(r *http.Request) dataloader.Config[K, V],
+) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			loader := dataloader.New(ctx, newConfig(r))
+			next.ServeHTTP(w, r.WithContext(loaderctx.NewContext(ctx, loader)))
+		})
+	}
+}
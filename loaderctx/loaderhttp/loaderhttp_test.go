@@ -0,0 +1,51 @@
+package loaderhttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.einride.tech/dataloader"
+	"go.einride.tech/dataloader/loaderctx"
+	"go.einride.tech/dataloader/loaderctx/loaderhttp"
+)
+
+func TestNewMiddleware(t *testing.T) {
+	newConfig := func(_ *http.Request) dataloader.Config[string, string] {
+		return dataloader.Config[string, string]{
+			Fetch: func(_ context.Context, keys []string) ([]string, error) {
+				values := make([]string, len(keys))
+				copy(values, keys)
+				return values, nil
+			},
+			Wait: 2 * time.Millisecond,
+		}
+	}
+	var loaders []*dataloader.Dataloader[string, string]
+	handler := loaderhttp.NewMiddleware(newConfig)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loader, err := loaderctx.FromContext[string, string](r.Context())
+		if err != nil {
+			t.Fatalf("FromContext: %v", err)
+		}
+		loaders = append(loaders, loader)
+		if _, err := loader.Load(r.Context(), "a"); err != nil {
+			t.Errorf("Load: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: got status %d, want %d", i, rec.Code, http.StatusOK)
+		}
+	}
+	if len(loaders) != 2 {
+		t.Fatalf("got %d loaders, want 2", len(loaders))
+	}
+	if loaders[0] == loaders[1] {
+		t.Error("expected each request to get its own Dataloader instance, got the same one twice")
+	}
+}